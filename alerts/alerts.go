@@ -0,0 +1,226 @@
+// Package alerts evaluates threshold rules against the same flattened
+// metric stream the history store consumes, and dispatches to pluggable
+// notifiers (webhook, SMTP, Slack-compatible incoming webhook) when a rule
+// has been breached for its configured duration.
+package alerts
+
+import (
+	"os"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Operator is a threshold comparison, e.g. "cpu.0 > 90".
+type Operator string
+
+const (
+	GreaterThan Operator = ">"
+	LessThan    Operator = "<"
+	NotEqual    Operator = "!="
+	Equal       Operator = "=="
+)
+
+// Rule is one alerting rule, e.g. "cpu.0 > 90 for 30s" becomes
+// {Metric: "cpu.0", Operator: ">", Threshold: 90, For: 30s}. Metrics that
+// are naturally string-valued (pm2's "status", say) are flattened to a
+// numeric stand-in instead — e.g. "pm2.<name>.up" is 1 when online and 0
+// otherwise — so `!=`/`==` rules still compare against a float64 threshold
+// like every other operator.
+type Rule struct {
+	ID        string        `yaml:"id"`
+	Metric    string        `yaml:"metric"`
+	Operator  Operator      `yaml:"operator"`
+	Threshold float64       `yaml:"threshold"`
+	For       time.Duration `yaml:"for"`
+	Cooldown  time.Duration `yaml:"cooldown"`
+}
+
+func (r Rule) breached(value float64) bool {
+	switch r.Operator {
+	case LessThan:
+		return value < r.Threshold
+	case NotEqual:
+		return value != r.Threshold
+	case Equal:
+		return value == r.Threshold
+	default:
+		return value > r.Threshold
+	}
+}
+
+// NotifiersConfig declares which notifiers are active; any field left nil
+// is skipped when the evaluator is assembled.
+type NotifiersConfig struct {
+	Webhook *WebhookNotifier `yaml:"webhook"`
+	Slack   *SlackNotifier   `yaml:"slack"`
+	SMTP    *SMTPNotifier    `yaml:"smtp"`
+}
+
+// Config is the `alerts:` section of config.yaml.
+type Config struct {
+	Rules     []Rule          `yaml:"rules"`
+	Notifiers NotifiersConfig `yaml:"notifiers"`
+}
+
+// ActiveNotifiers returns every configured notifier, skipping unset ones.
+func (c *Config) ActiveNotifiers() []Notifier {
+	var notifiers []Notifier
+	if c.Notifiers.Webhook != nil {
+		notifiers = append(notifiers, c.Notifiers.Webhook)
+	}
+	if c.Notifiers.Slack != nil {
+		notifiers = append(notifiers, c.Notifiers.Slack)
+	}
+	if c.Notifiers.SMTP != nil {
+		notifiers = append(notifiers, c.Notifiers.SMTP)
+	}
+	return notifiers
+}
+
+type configFile struct {
+	Alerts Config `yaml:"alerts"`
+}
+
+// LoadConfig reads the `alerts:` section out of the YAML file at path. A
+// missing file yields an empty rule set rather than an error, since
+// alerting is optional.
+func LoadConfig(path string) (*Config, error) {
+	file := &configFile{}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &file.Alerts, nil
+		}
+		return nil, err
+	}
+	if err := yaml.Unmarshal(data, file); err != nil {
+		return nil, err
+	}
+	return &file.Alerts, nil
+}
+
+// Alert is the current firing state of one rule.
+type Alert struct {
+	RuleID        string    `json:"rule_id"`
+	Metric        string    `json:"metric"`
+	Value         float64   `json:"value"`
+	FiringSince   time.Time `json:"firing_since"`
+	SilencedUntil time.Time `json:"silenced_until,omitempty"`
+}
+
+type breachState struct {
+	since time.Time
+}
+
+// Evaluator tracks rule breach duration across samples and dispatches to
+// Notifiers once a rule has been breached continuously for its For
+// duration, honoring per-rule cooldown and silences.
+type Evaluator struct {
+	rules     []Rule
+	notifiers []Notifier
+
+	// OnNotifyError, if set, is called whenever a notifier fails to
+	// dispatch an alert. Kept decoupled from any particular logger so this
+	// package has no dependency on the rest of the module.
+	OnNotifyError func(ruleID string, err error)
+
+	mu        sync.Mutex
+	breaches  map[string]*breachState // rule id -> ongoing breach
+	firing    map[string]Alert        // rule id -> current alert
+	lastFired map[string]time.Time    // rule id -> last dispatch time
+	silenced  map[string]time.Time    // rule id -> silenced until
+}
+
+func NewEvaluator(rules []Rule, notifiers []Notifier) *Evaluator {
+	return &Evaluator{
+		rules:     rules,
+		notifiers: notifiers,
+		breaches:  make(map[string]*breachState),
+		firing:    make(map[string]Alert),
+		lastFired: make(map[string]time.Time),
+		silenced:  make(map[string]time.Time),
+	}
+}
+
+// Run consumes flattened metric samples until samples is closed. It's meant
+// to be started in its own goroutine reading off a fan-out channel so a
+// slow notifier never blocks metric collection or WebSocket writes.
+func (e *Evaluator) Run(samples <-chan map[string]float64) {
+	for sample := range samples {
+		e.evaluate(sample)
+	}
+}
+
+func (e *Evaluator) evaluate(sample map[string]float64) {
+	now := time.Now()
+
+	for _, rule := range e.rules {
+		value, ok := sample[rule.Metric]
+		if !ok {
+			continue
+		}
+
+		e.mu.Lock()
+		if !rule.breached(value) {
+			delete(e.breaches, rule.ID)
+			delete(e.firing, rule.ID)
+			e.mu.Unlock()
+			continue
+		}
+
+		state, ongoing := e.breaches[rule.ID]
+		if !ongoing {
+			state = &breachState{since: now}
+			e.breaches[rule.ID] = state
+		}
+		alert := Alert{RuleID: rule.ID, Metric: rule.Metric, Value: value, FiringSince: state.since}
+		if until, silenced := e.silenced[rule.ID]; silenced && now.Before(until) {
+			alert.SilencedUntil = until
+			e.firing[rule.ID] = alert
+			e.mu.Unlock()
+			continue
+		}
+		e.firing[rule.ID] = alert
+
+		breachedLongEnough := now.Sub(state.since) >= rule.For
+		cooledDown := now.Sub(e.lastFired[rule.ID]) >= rule.Cooldown
+		shouldDispatch := breachedLongEnough && cooledDown
+		if shouldDispatch {
+			e.lastFired[rule.ID] = now
+		}
+		e.mu.Unlock()
+
+		if shouldDispatch {
+			for _, n := range e.notifiers {
+				go func(rule Rule, n Notifier) {
+					if err := n.Notify(alert); err != nil && e.OnNotifyError != nil {
+						e.OnNotifyError(rule.ID, err)
+					}
+				}(rule, n)
+			}
+		}
+	}
+}
+
+// Firing returns every rule currently in breach, including silenced ones.
+func (e *Evaluator) Firing() []Alert {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	alerts := make([]Alert, 0, len(e.firing))
+	for _, a := range e.firing {
+		alerts = append(alerts, a)
+	}
+	return alerts
+}
+
+// Silence suppresses dispatch for ruleID until duration from now. The rule
+// still appears in Firing() (with SilencedUntil set) so it isn't hidden,
+// just muted.
+func (e *Evaluator) Silence(ruleID string, duration time.Duration) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.silenced[ruleID] = time.Now().Add(duration)
+}
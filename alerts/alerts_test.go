@@ -0,0 +1,119 @@
+package alerts
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRuleBreached(t *testing.T) {
+	tests := []struct {
+		name     string
+		rule     Rule
+		value    float64
+		breached bool
+	}{
+		{"greater than, over", Rule{Operator: GreaterThan, Threshold: 90}, 95, true},
+		{"greater than, under", Rule{Operator: GreaterThan, Threshold: 90}, 50, false},
+		{"less than, under", Rule{Operator: LessThan, Threshold: 5000000000}, 1000000000, true},
+		{"less than, over", Rule{Operator: LessThan, Threshold: 5000000000}, 9000000000, false},
+		{"not equal, differs", Rule{Operator: NotEqual, Threshold: 1}, 0, true},
+		{"not equal, matches", Rule{Operator: NotEqual, Threshold: 1}, 1, false},
+		{"equal, matches", Rule{Operator: Equal, Threshold: 1}, 1, true},
+		{"equal, differs", Rule{Operator: Equal, Threshold: 1}, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.rule.breached(tt.value); got != tt.breached {
+				t.Errorf("breached(%v) = %v, want %v", tt.value, got, tt.breached)
+			}
+		})
+	}
+}
+
+// fakeNotifier records which rule ID each dispatch was made for.
+type fakeNotifier struct {
+	mu      sync.Mutex
+	alerts  []Alert
+	wg      *sync.WaitGroup
+	failAll bool
+}
+
+func (f *fakeNotifier) Notify(alert Alert) error {
+	f.mu.Lock()
+	f.alerts = append(f.alerts, alert)
+	f.mu.Unlock()
+	if f.wg != nil {
+		f.wg.Done()
+	}
+	if f.failAll {
+		return errors.New("fake notifier failure")
+	}
+	return nil
+}
+
+// TestEvaluatorDispatchUsesCorrectRule reproduces a regression where the
+// dispatch goroutine closed over the shared loop variable `rule` instead of
+// taking it as a parameter, so OnNotifyError (and, by the same bug shape,
+// any per-rule data read after the goroutine is scheduled) could report the
+// wrong rule once more than one rule was breached in the same tick.
+func TestEvaluatorDispatchUsesCorrectRule(t *testing.T) {
+	notifier := &fakeNotifier{failAll: true}
+	var wg sync.WaitGroup
+	wg.Add(2)
+
+	rules := []Rule{
+		{ID: "rule-a", Metric: "a", Operator: GreaterThan, Threshold: 0},
+		{ID: "rule-b", Metric: "b", Operator: GreaterThan, Threshold: 0},
+	}
+	e := NewEvaluator(rules, []Notifier{notifier})
+
+	var mu sync.Mutex
+	reportedRuleIDs := make(map[string]bool)
+	e.OnNotifyError = func(ruleID string, err error) {
+		mu.Lock()
+		reportedRuleIDs[ruleID] = true
+		mu.Unlock()
+		wg.Done()
+	}
+
+	e.evaluate(map[string]float64{"a": 1, "b": 1})
+	wg.Wait()
+
+	mu.Lock()
+	if !reportedRuleIDs["rule-a"] || !reportedRuleIDs["rule-b"] {
+		t.Errorf("expected OnNotifyError for both rule-a and rule-b, got %v", reportedRuleIDs)
+	}
+	mu.Unlock()
+
+	notifier.mu.Lock()
+	defer notifier.mu.Unlock()
+	if len(notifier.alerts) != 2 {
+		t.Fatalf("expected 2 dispatched alerts, got %d", len(notifier.alerts))
+	}
+	seen := map[string]bool{}
+	for _, a := range notifier.alerts {
+		seen[a.RuleID] = true
+	}
+	if !seen["rule-a"] || !seen["rule-b"] {
+		t.Errorf("expected dispatch for both rule-a and rule-b, got %+v", notifier.alerts)
+	}
+}
+
+func TestEvaluatorRespectsCooldown(t *testing.T) {
+	notifier := &fakeNotifier{}
+	rules := []Rule{{ID: "r", Metric: "m", Operator: GreaterThan, Threshold: 0, Cooldown: time.Hour}}
+	e := NewEvaluator(rules, []Notifier{notifier})
+
+	e.evaluate(map[string]float64{"m": 1})
+	e.evaluate(map[string]float64{"m": 1})
+	time.Sleep(10 * time.Millisecond)
+
+	notifier.mu.Lock()
+	defer notifier.mu.Unlock()
+	if len(notifier.alerts) != 1 {
+		t.Errorf("expected exactly 1 dispatch within cooldown window, got %d", len(notifier.alerts))
+	}
+}
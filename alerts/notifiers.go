@@ -0,0 +1,117 @@
+package alerts
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/smtp"
+	"time"
+)
+
+// Notifier delivers a firing Alert to some external system.
+type Notifier interface {
+	Notify(alert Alert) error
+}
+
+// WebhookNotifier POSTs the alert as JSON to a configured URL.
+type WebhookNotifier struct {
+	URL    string `yaml:"url"`
+	Client *http.Client
+}
+
+func (w *WebhookNotifier) Notify(alert Alert) error {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+
+	client := w.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	resp, err := client.Post(w.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook notifier: unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+// SlackNotifier posts to a Slack-compatible incoming webhook URL, wrapping
+// the alert in the {"text": "..."} shape Slack (and compatible chat tools)
+// expect instead of the raw Alert JSON.
+type SlackNotifier struct {
+	WebhookURL string `yaml:"webhook_url"`
+	Client     *http.Client
+}
+
+func (s *SlackNotifier) Notify(alert Alert) error {
+	text := fmt.Sprintf("alert %s firing since %s: %s = %.2f", alert.RuleID, alert.FiringSince.Format(time.RFC3339), alert.Metric, alert.Value)
+	body, err := json.Marshal(map[string]string{"text": text})
+	if err != nil {
+		return err
+	}
+
+	client := s.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	resp, err := client.Post(s.WebhookURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("slack notifier: unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+// SMTPNotifier emails the alert through a configured SMTP relay.
+type SMTPNotifier struct {
+	Addr     string   `yaml:"addr"`
+	Username string   `yaml:"username"`
+	Password string   `yaml:"password"`
+	From     string   `yaml:"from"`
+	To       []string `yaml:"to"`
+}
+
+func (s *SMTPNotifier) Notify(alert Alert) error {
+	subject := fmt.Sprintf("[alert] %s", alert.RuleID)
+	body := fmt.Sprintf("Rule %s has been firing since %s.\nMetric: %s\nValue: %.2f\n",
+		alert.RuleID, alert.FiringSince.Format(time.RFC3339), alert.Metric, alert.Value)
+	msg := []byte(fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\n\r\n%s",
+		s.From, joinAddrs(s.To), subject, body))
+
+	var auth smtp.Auth
+	if s.Username != "" {
+		auth = smtp.PlainAuth("", s.Username, s.Password, addrHost(s.Addr))
+	}
+	return smtp.SendMail(s.Addr, auth, s.From, s.To, msg)
+}
+
+func joinAddrs(addrs []string) string {
+	out := ""
+	for i, a := range addrs {
+		if i > 0 {
+			out += ", "
+		}
+		out += a
+	}
+	return out
+}
+
+func addrHost(addr string) string {
+	for i := len(addr) - 1; i >= 0; i-- {
+		if addr[i] == ':' {
+			return addr[:i]
+		}
+	}
+	return addr
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spidey52/server-managment/alerts"
+)
+
+func alertsHandler(evaluator *alerts.Evaluator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, evaluator.Firing())
+	}
+}
+
+func alertsSilenceHandler(evaluator *alerts.Evaluator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		duration, err := time.ParseDuration(c.DefaultQuery("duration", "1h"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_duration", "message": err.Error()})
+			return
+		}
+		evaluator.Silence(c.Param("id"), duration)
+		c.JSON(http.StatusOK, gin.H{"id": c.Param("id"), "silenced_for": duration.String()})
+	}
+}
@@ -0,0 +1,314 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	dockertypes "github.com/docker/docker/api/types"
+	"github.com/docker/docker/client"
+)
+
+// ContainerStats is one adjacent workload's resource usage, whether that
+// workload is a Docker container listed via the Engine API or (when no
+// Docker socket is reachable) this process's own cgroup.
+type ContainerStats struct {
+	ID             string  `json:"id"`
+	Name           string  `json:"name"`
+	Image          string  `json:"image"`
+	State          string  `json:"state"`
+	CPUPercent     float64 `json:"cpu_percent"`
+	MemoryUsage    uint64  `json:"memory_usage"`
+	MemoryLimit    uint64  `json:"memory_limit"`
+	NetworkRxBytes uint64  `json:"network_rx_bytes"`
+	NetworkTxBytes uint64  `json:"network_tx_bytes"`
+}
+
+const dockerSocketPath = "/var/run/docker.sock"
+
+func dockerSocketPresent() bool {
+	_, err := os.Stat(dockerSocketPath)
+	return err == nil
+}
+
+func runningInContainer() bool {
+	if _, err := os.Stat("/.dockerenv"); err == nil {
+		return true
+	}
+	data, err := os.ReadFile("/proc/1/cgroup")
+	if err != nil {
+		return false
+	}
+	content := string(data)
+	return strings.Contains(content, "docker") || strings.Contains(content, "kubepods")
+}
+
+// ContainerCollector reports per-container usage. When the Docker socket is
+// reachable it lists every container via the Engine API; otherwise, if this
+// process itself looks like it's running inside a container, it falls back
+// to reading its own cgroup so there's still *some* visibility without a
+// daemon connection. With neither signal present, Collect returns no data.
+type ContainerCollector struct {
+	docker *client.Client
+
+	mu          sync.Mutex
+	selfCPUUsec uint64
+	selfSampled time.Time
+	dockerCPU   map[string]dockerCPUSample
+}
+
+func NewContainerCollector() (*ContainerCollector, error) {
+	c := &ContainerCollector{dockerCPU: make(map[string]dockerCPUSample)}
+	if !dockerSocketPresent() {
+		return c, nil
+	}
+	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if err != nil {
+		return nil, err
+	}
+	c.docker = cli
+	return c, nil
+}
+
+// Enabled reports whether Collect has any data source to report from.
+func (c *ContainerCollector) Enabled() bool {
+	return c.docker != nil || runningInContainer()
+}
+
+func (c *ContainerCollector) Collect(ctx context.Context) ([]ContainerStats, error) {
+	if c.docker != nil {
+		return c.collectDocker(ctx)
+	}
+	if runningInContainer() {
+		if stats := c.collectSelfCgroup(); stats != nil {
+			return []ContainerStats{*stats}, nil
+		}
+	}
+	return nil, nil
+}
+
+func (c *ContainerCollector) collectDocker(ctx context.Context) ([]ContainerStats, error) {
+	containers, err := c.docker.ContainerList(ctx, dockertypes.ContainerListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]ContainerStats, 0, len(containers))
+	for _, ctr := range containers {
+		resp, err := c.docker.ContainerStatsOneShot(ctx, ctr.ID)
+		if err != nil {
+			continue
+		}
+		var raw dockertypes.StatsJSON
+		decodeErr := json.NewDecoder(resp.Body).Decode(&raw)
+		resp.Body.Close()
+		if decodeErr != nil {
+			continue
+		}
+
+		name := ctr.ID
+		if len(ctr.Names) > 0 {
+			name = strings.TrimPrefix(ctr.Names[0], "/")
+		}
+
+		var rx, tx uint64
+		for _, n := range raw.Networks {
+			rx += n.RxBytes
+			tx += n.TxBytes
+		}
+
+		out = append(out, ContainerStats{
+			ID:             shortID(ctr.ID),
+			Name:           name,
+			Image:          ctr.Image,
+			State:          ctr.State,
+			CPUPercent:     truncateToDecimals(c.dockerCPUPercent(ctr.ID, raw), 2),
+			MemoryUsage:    raw.MemoryStats.Usage,
+			MemoryLimit:    raw.MemoryStats.Limit,
+			NetworkRxBytes: rx,
+			NetworkTxBytes: tx,
+		})
+	}
+	return out, nil
+}
+
+func shortID(id string) string {
+	if len(id) > 12 {
+		return id[:12]
+	}
+	return id
+}
+
+// dockerCPUSample is the previous tick's raw CPU counters for one
+// container, keyed by full container ID.
+type dockerCPUSample struct {
+	cpuUsage    uint64
+	systemUsage uint64
+}
+
+// dockerCPUPercent mirrors `docker stats`: usage delta over the host's
+// total CPU-time delta since the last tick, scaled by core count.
+// ContainerStatsOneShot never populates PreCPUStats (that field is only
+// filled in by the streaming stats endpoint), so it's always zero here -
+// the collector keeps its own previous sample per container instead, the
+// same way collectSelfCgroup keeps one for this process's own cgroup.
+func (c *ContainerCollector) dockerCPUPercent(id string, raw dockertypes.StatsJSON) float64 {
+	cpuUsage := raw.CPUStats.CPUUsage.TotalUsage
+	systemUsage := raw.CPUStats.SystemUsage
+
+	c.mu.Lock()
+	prev, ok := c.dockerCPU[id]
+	c.dockerCPU[id] = dockerCPUSample{cpuUsage: cpuUsage, systemUsage: systemUsage}
+	c.mu.Unlock()
+
+	if !ok || cpuUsage <= prev.cpuUsage || systemUsage <= prev.systemUsage {
+		return 0
+	}
+
+	cpuDelta := float64(cpuUsage - prev.cpuUsage)
+	systemDelta := float64(systemUsage - prev.systemUsage)
+	return (cpuDelta / systemDelta) * dockerCoreCount(raw) * 100.0
+}
+
+// dockerCoreCount returns the number of CPUs the container was scheduled
+// against. OnlineCPUs is the field Docker added specifically to cover
+// cgroup v2 hosts, where CPUUsage.PercpuUsage is left empty ("PercpuUsage
+// is not supported" per Docker's own stats conversion) - falling back to
+// len(PercpuUsage) would silently under-report CPU% by the host's core
+// count on any cgroup v2 daemon. len(PercpuUsage) is only used as a last
+// resort, and 1 if neither is populated.
+func dockerCoreCount(raw dockertypes.StatsJSON) float64 {
+	if raw.CPUStats.OnlineCPUs > 0 {
+		return float64(raw.CPUStats.OnlineCPUs)
+	}
+	if n := len(raw.CPUStats.CPUUsage.PercpuUsage); n > 0 {
+		return float64(n)
+	}
+	return 1
+}
+
+// collectSelfCgroup reads this process's own cgroup (v2 first, falling
+// back to v1) to report usage when there's no Docker socket to ask.
+func (c *ContainerCollector) collectSelfCgroup() *ContainerStats {
+	usage, limit, cpuUsec, ok := readCgroupV2()
+	if !ok {
+		usage, limit, cpuUsec, ok = readCgroupV1()
+	}
+	if !ok {
+		return nil
+	}
+
+	c.mu.Lock()
+	now := time.Now()
+	var cpuPercent float64
+	if !c.selfSampled.IsZero() && cpuUsec > c.selfCPUUsec {
+		elapsed := now.Sub(c.selfSampled).Seconds()
+		if elapsed > 0 {
+			cpuPercent = truncateToDecimals(float64(cpuUsec-c.selfCPUUsec)/10000.0/elapsed, 2)
+		}
+	}
+	c.selfCPUUsec = cpuUsec
+	c.selfSampled = now
+	c.mu.Unlock()
+
+	rx, tx := readSelfNetDev()
+
+	hostname, _ := os.Hostname()
+	return &ContainerStats{
+		ID:             hostname,
+		Name:           hostname,
+		State:          "self",
+		CPUPercent:     cpuPercent,
+		MemoryUsage:    usage,
+		MemoryLimit:    limit,
+		NetworkRxBytes: rx,
+		NetworkTxBytes: tx,
+	}
+}
+
+// readSelfNetDev sums receive/transmit bytes across every interface in this
+// process's network namespace, Lo included, by parsing /proc/self/net/dev's
+// whitespace-separated columns (the same data `cat /proc/self/net/dev` prints).
+func readSelfNetDev() (rx, tx uint64) {
+	data, err := os.ReadFile("/proc/self/net/dev")
+	if err != nil {
+		return 0, 0
+	}
+	lines := strings.Split(string(data), "\n")
+	if len(lines) <= 2 {
+		return 0, 0
+	}
+	for _, line := range lines[2:] {
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		fields := strings.Fields(parts[1])
+		if len(fields) < 9 {
+			continue
+		}
+		if v, err := strconv.ParseUint(fields[0], 10, 64); err == nil {
+			rx += v
+		}
+		if v, err := strconv.ParseUint(fields[8], 10, 64); err == nil {
+			tx += v
+		}
+	}
+	return rx, tx
+}
+
+// readCgroupV2 returns (memory usage, memory limit, cpu usage in
+// microseconds, ok).
+func readCgroupV2() (uint64, uint64, uint64, bool) {
+	usage, err := readUintFile("/sys/fs/cgroup/memory.current")
+	if err != nil {
+		return 0, 0, 0, false
+	}
+	limit, err := readUintFile("/sys/fs/cgroup/memory.max")
+	if err != nil {
+		limit = 0
+	}
+
+	data, err := os.ReadFile("/sys/fs/cgroup/cpu.stat")
+	if err != nil {
+		return usage, limit, 0, true
+	}
+	var cpuUsec uint64
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[0] == "usage_usec" {
+			cpuUsec, _ = strconv.ParseUint(fields[1], 10, 64)
+		}
+	}
+	return usage, limit, cpuUsec, true
+}
+
+// readCgroupV1 returns (memory usage, memory limit, cpu usage in
+// microseconds, ok).
+func readCgroupV1() (uint64, uint64, uint64, bool) {
+	usage, err := readUintFile("/sys/fs/cgroup/memory/memory.usage_in_bytes")
+	if err != nil {
+		return 0, 0, 0, false
+	}
+	limit, err := readUintFile("/sys/fs/cgroup/memory/memory.limit_in_bytes")
+	if err != nil {
+		limit = 0
+	}
+	cpuNanos, err := readUintFile("/sys/fs/cgroup/cpuacct/cpuacct.usage")
+	if err != nil {
+		return usage, limit, 0, true
+	}
+	return usage, limit, cpuNanos / 1000, true
+}
+
+func readUintFile(path string) (uint64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+}
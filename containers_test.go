@@ -0,0 +1,69 @@
+package main
+
+import (
+	"testing"
+
+	dockertypes "github.com/docker/docker/api/types"
+)
+
+// TestDockerCPUPercentUsesPreviousSample reproduces a regression where CPU
+// percent was computed from raw.PreCPUStats, which ContainerStatsOneShot
+// never populates - every reading came out as either 0 or a lifetime
+// average instead of a live delta. The collector must keep its own
+// previous sample per container instead.
+func TestDockerCPUPercentUsesPreviousSample(t *testing.T) {
+	c, _ := NewContainerCollector()
+	const id = "abc123"
+
+	statsAt := func(cpuUsage, systemUsage uint64) dockertypes.StatsJSON {
+		var s dockertypes.StatsJSON
+		s.CPUStats.CPUUsage.TotalUsage = cpuUsage
+		s.CPUStats.CPUUsage.PercpuUsage = []uint64{0}
+		s.CPUStats.SystemUsage = systemUsage
+		return s
+	}
+
+	if got := c.dockerCPUPercent(id, statsAt(1000, 10000)); got != 0 {
+		t.Fatalf("first sample (no history) should report 0, got %v", got)
+	}
+
+	got := c.dockerCPUPercent(id, statsAt(1500, 10500))
+	want := (float64(500) / float64(500)) * 1 * 100.0
+	if got != want {
+		t.Errorf("second sample delta = %v, want %v", got, want)
+	}
+}
+
+// TestDockerCPUPercentUsesOnlineCPUsOnCgroupV2 reproduces a regression
+// where core count came from len(PercpuUsage), which Docker's own stats
+// conversion never populates on cgroup v2 hosts ("PercpuUsage is not
+// supported"). That silently fell back to 1 core, under-reporting
+// CPUPercent by a factor of the host's real core count. OnlineCPUs is
+// populated on both v1 and v2 and must be preferred.
+func TestDockerCPUPercentUsesOnlineCPUsOnCgroupV2(t *testing.T) {
+	c, _ := NewContainerCollector()
+	const id = "def456"
+
+	statsAt := func(cpuUsage, systemUsage uint64) dockertypes.StatsJSON {
+		var s dockertypes.StatsJSON
+		s.CPUStats.CPUUsage.TotalUsage = cpuUsage
+		s.CPUStats.SystemUsage = systemUsage
+		s.CPUStats.OnlineCPUs = 4
+		// cgroup v2: PercpuUsage is left empty by Docker.
+		return s
+	}
+
+	c.dockerCPUPercent(id, statsAt(1000, 10000))
+	got := c.dockerCPUPercent(id, statsAt(1500, 10500))
+	want := (float64(500) / float64(500)) * 4 * 100.0
+	if got != want {
+		t.Errorf("cgroup v2 sample (empty PercpuUsage) = %v, want %v (should scale by OnlineCPUs)", got, want)
+	}
+}
+
+func TestReadSelfNetDevParsesColumns(t *testing.T) {
+	rx, tx := readSelfNetDev()
+	if rx == 0 && tx == 0 {
+		t.Skip("no network counters available in this sandbox")
+	}
+}
@@ -0,0 +1,271 @@
+package main
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Point is a single downsampled (timestamp, value) observation for a metric.
+type Point struct {
+	Timestamp int64   `json:"t"`
+	Value     float64 `json:"v"`
+}
+
+// retentionTier describes one ring buffer: how far apart samples are and how
+// many of them are kept before the oldest is evicted.
+type retentionTier struct {
+	Interval time.Duration
+	Capacity int
+}
+
+// defaultTiers mirrors the "1s x 3600, 10s x 8640, 1m x 10080" retention
+// policy: roughly an hour of raw data, a day of 10s rollups, and a week of
+// minute rollups.
+var defaultTiers = []retentionTier{
+	{Interval: time.Second, Capacity: 3600},
+	{Interval: 10 * time.Second, Capacity: 8640},
+	{Interval: time.Minute, Capacity: 10080},
+}
+
+// ring is a fixed-capacity circular buffer of points for a single tier.
+type ring struct {
+	points []Point
+	next   int
+	full   bool
+}
+
+func newRing(capacity int) *ring {
+	return &ring{points: make([]Point, 0, capacity)}
+}
+
+func (r *ring) add(p Point) {
+	if cap(r.points) == 0 {
+		return
+	}
+	if len(r.points) < cap(r.points) {
+		r.points = append(r.points, p)
+		return
+	}
+	r.points[r.next] = p
+	r.next = (r.next + 1) % cap(r.points)
+	r.full = true
+}
+
+// ordered returns the ring's points sorted oldest-first.
+func (r *ring) ordered() []Point {
+	if !r.full {
+		out := make([]Point, len(r.points))
+		copy(out, r.points)
+		return out
+	}
+	out := make([]Point, 0, len(r.points))
+	out = append(out, r.points[r.next:]...)
+	out = append(out, r.points[:r.next]...)
+	return out
+}
+
+// metricSeries holds one ring per retention tier for a single metric name,
+// plus the in-flight accumulator used to downsample raw samples into each
+// coarser tier as they age.
+type metricSeries struct {
+	tiers        []*ring
+	accum        []float64
+	accumStart   []int64
+	lastTierTime []int64
+}
+
+func newMetricSeries() *metricSeries {
+	s := &metricSeries{
+		tiers:        make([]*ring, len(defaultTiers)),
+		accum:        make([]float64, len(defaultTiers)),
+		accumStart:   make([]int64, len(defaultTiers)),
+		lastTierTime: make([]int64, len(defaultTiers)),
+	}
+	for i, tier := range defaultTiers {
+		s.tiers[i] = newRing(tier.Capacity)
+	}
+	return s
+}
+
+// add pushes a raw sample into tier 0 and rolls it into every coarser tier
+// using mean downsampling once that tier's interval has elapsed.
+func (s *metricSeries) add(ts int64, value float64) {
+	for i, tier := range defaultTiers {
+		if i == 0 {
+			s.tiers[0].add(Point{Timestamp: ts, Value: value})
+			s.lastTierTime[0] = ts
+			continue
+		}
+
+		bucketStart := ts - ts%int64(tier.Interval/time.Second)
+		if s.accumStart[i] == 0 {
+			s.accumStart[i] = bucketStart
+		}
+		if bucketStart != s.accumStart[i] {
+			s.tiers[i].add(Point{Timestamp: s.accumStart[i], Value: s.accum[i] / float64(countOrOne(s.lastTierTime[i]))})
+			s.accum[i] = 0
+			s.lastTierTime[i] = 0
+			s.accumStart[i] = bucketStart
+		}
+		s.accum[i] += value
+		s.lastTierTime[i]++
+	}
+}
+
+func countOrOne(n int64) int64 {
+	if n == 0 {
+		return 1
+	}
+	return n
+}
+
+// HistoryStore is an in-process, per-metric ring-buffer store used for
+// incident forensics: it lets /history and /snapshot answer "what did this
+// metric look like around time X" without an external time-series database.
+type HistoryStore struct {
+	mu     sync.Mutex
+	series map[string]*metricSeries
+}
+
+func NewHistoryStore() *HistoryStore {
+	return &HistoryStore{series: make(map[string]*metricSeries)}
+}
+
+// Add records one sample for every flattened metric in flat at timestamp ts
+// (unix seconds).
+func (h *HistoryStore) Add(ts int64, flat map[string]float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for name, value := range flat {
+		s, ok := h.series[name]
+		if !ok {
+			s = newMetricSeries()
+			h.series[name] = s
+		}
+		s.add(ts, value)
+	}
+}
+
+// Query returns the points for metric between from and to, merging across
+// every retention tier that overlaps the range. A query spanning more than
+// the raw tier's retention window needs points from the coarser tiers too
+// (e.g. [now-7200, now] with only an hour of tier-0 history), so tiers are
+// never queried in isolation: each tier contributes whatever points of it
+// fall inside [from, to], deduped by timestamp with the finer tier winning
+// where two tiers both cover the same instant. There is no step parameter:
+// neither Query nor /history reads one, and downsampling granularity is
+// determined by which tier holds the data.
+func (h *HistoryStore) Query(metric string, from, to int64) []Point {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s, ok := h.series[metric]
+	if !ok {
+		return nil
+	}
+
+	seen := make(map[int64]bool)
+	var out []Point
+	for i := 0; i < len(s.tiers); i++ {
+		for _, p := range s.tiers[i].ordered() {
+			if p.Timestamp < from || p.Timestamp > to || seen[p.Timestamp] {
+				continue
+			}
+			seen[p.Timestamp] = true
+			out = append(out, p)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Timestamp < out[j].Timestamp })
+	return out
+}
+
+// Snapshot returns the most recent value recorded for every metric.
+func (h *HistoryStore) Snapshot() map[string]Point {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	out := make(map[string]Point, len(h.series))
+	for name, s := range h.series {
+		points := s.tiers[0].ordered()
+		if len(points) == 0 {
+			continue
+		}
+		out[name] = points[len(points)-1]
+	}
+	return out
+}
+
+// flattenMetrics converts a Metrics sample into the flat metric-name ->
+// value form the history store keys on (cpu.<i>, mem, disk,
+// net.<iface>.{sent,recv}, pm2.<name>.{cpu,mem}), plus a few derived metrics
+// (memory.used_pct, disk.free, pm2.<name>.up) that alert rules reference but
+// that aren't otherwise present as a single raw field.
+func flattenMetrics(m Metrics) map[string]float64 {
+	out := make(map[string]float64)
+	for i, v := range m.CPU {
+		out["cpu."+strconv.Itoa(i)] = v
+	}
+	out["mem"] = float64(m.Memory.Used)
+	out["disk"] = float64(m.Disk.Used)
+	out["disk.free"] = float64(m.Disk.Free)
+	if m.Memory.Total > 0 {
+		out["memory.used_pct"] = float64(m.Memory.Used) / float64(m.Memory.Total) * 100
+	}
+	for _, n := range m.Network {
+		out["net."+n.Name+".sent"] = float64(n.BytesSent)
+		out["net."+n.Name+".recv"] = float64(n.BytesRecv)
+	}
+	for _, p := range m.PM2 {
+		out["pm2."+p.Name+".cpu"] = float64(p.Monit.CPU)
+		out["pm2."+p.Name+".mem"] = float64(p.Monit.Memory)
+		up := 0.0
+		if p.PM2Env.Status == "online" {
+			up = 1.0
+		}
+		out["pm2."+p.Name+".up"] = up
+	}
+	return out
+}
+
+func historyHandler(store *HistoryStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		metric := c.Query("metric")
+		if metric == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "metric query param is required"})
+			return
+		}
+
+		now := time.Now().Unix()
+		from := parseUnixOr(c.Query("from"), now-3600)
+		to := parseUnixOr(c.Query("to"), now)
+
+		c.JSON(http.StatusOK, gin.H{
+			"metric": metric,
+			"from":   from,
+			"to":     to,
+			"points": store.Query(metric, from, to),
+		})
+	}
+}
+
+func snapshotHandler(store *HistoryStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, store.Snapshot())
+	}
+}
+
+func parseUnixOr(raw string, fallback int64) int64 {
+	if raw == "" {
+		return fallback
+	}
+	v, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return fallback
+	}
+	return v
+}
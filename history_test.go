@@ -0,0 +1,49 @@
+package main
+
+import "testing"
+
+// TestHistoryStoreQueryPrefersFinestTier reproduces a regression where Query
+// walked tiers coarsest-first: a request for a range still covered by raw
+// (tier 0) data would incorrectly return 10s/1m rollups instead of the raw
+// points.
+func TestHistoryStoreQueryPrefersFinestTier(t *testing.T) {
+	store := NewHistoryStore()
+	// 25 samples close the 10s rollup bucket starting at ts=10, so tier 1
+	// holds a mean point at a timestamp tier 0 also covers.
+	for ts := int64(1); ts <= 25; ts++ {
+		store.Add(ts, map[string]float64{"cpu.0": float64(ts)})
+	}
+
+	points := store.Query("cpu.0", 1, 25)
+	if len(points) != 25 {
+		t.Fatalf("expected 25 raw points from tier 0, got %d: %+v", len(points), points)
+	}
+	for i, p := range points {
+		want := float64(i + 1)
+		if p.Value != want {
+			t.Errorf("point %d: got value %v, want %v (rollup value would not match raw samples)", i, p.Value, want)
+		}
+	}
+}
+
+// TestHistoryStoreQuerySpansTiers reproduces a regression where Query
+// stopped at the first tier with any overlapping points: a range straddling
+// the tier-0 retention boundary would return only the finest tier's partial
+// slice and silently drop the older portion that was still held in a
+// coarser tier.
+func TestHistoryStoreQuerySpansTiers(t *testing.T) {
+	store := NewHistoryStore()
+	for ts := int64(1); ts <= 7200; ts++ {
+		store.Add(ts, map[string]float64{"cpu.0": float64(ts)})
+	}
+
+	// tier 0 only retains the most recent 3600 samples, so the first half of
+	// the range now lives solely in the 10s rollup tier.
+	points := store.Query("cpu.0", 1, 7200)
+	if first := points[0].Timestamp; first >= 3601 {
+		t.Errorf("expected a point covering the older half of the range, earliest was ts=%d (older portion of range was dropped)", first)
+	}
+	if last := points[len(points)-1].Timestamp; last != 7200 {
+		t.Errorf("expected latest point at ts=7200, got ts=%d", last)
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+)
+
+const (
+	clientSendBuffer = 16
+	writeWait        = 10 * time.Second
+	pongWait         = 60 * time.Second
+	pingPeriod       = (pongWait * 9) / 10
+)
+
+// wsClient owns one WebSocket connection. Metrics broadcasts and backfill
+// replies are handed to it over a small buffered channel rather than
+// written directly, so one slow client blocks only itself: if its buffer
+// fills, the oldest queued message is dropped in favor of the newest one
+// and dropped_samples_total is incremented.
+type wsClient struct {
+	conn   *websocket.Conn
+	send   chan []byte
+	logger *zap.Logger
+}
+
+func newClient(conn *websocket.Conn, logger *zap.Logger) *wsClient {
+	return &wsClient{conn: conn, send: make(chan []byte, clientSendBuffer), logger: logger}
+}
+
+// enqueue hands data to the client's writePump, dropping the oldest queued
+// message instead of blocking if the buffer is full.
+func (c *wsClient) enqueue(data []byte) {
+	select {
+	case c.send <- data:
+		return
+	default:
+	}
+
+	select {
+	case <-c.send:
+	default:
+	}
+	select {
+	case c.send <- data:
+	default:
+	}
+	promDroppedSamplesTotal.Inc()
+}
+
+// writePump owns all writes to the connection: queued broadcasts/backfills
+// and periodic pings. It exits (closing the connection) on the first write
+// error or once send is closed by readPump.
+func (c *wsClient) writePump() {
+	ticker := time.NewTicker(pingPeriod)
+	defer func() {
+		ticker.Stop()
+		c.conn.Close()
+	}()
+
+	for {
+		select {
+		case data, ok := <-c.send:
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if !ok {
+				c.conn.WriteMessage(websocket.CloseMessage, []byte{})
+				return
+			}
+			if err := c.conn.WriteMessage(websocket.TextMessage, data); err != nil {
+				c.logger.Warn("failed to write to websocket", zap.Error(err))
+				return
+			}
+		case <-ticker.C:
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// readPump reads subscribe requests off the connection and reaps dead
+// connections via the pong-driven read deadline: if no pong (or other
+// message) arrives within pongWait, ReadMessage times out and the
+// connection is torn down.
+func (c *wsClient) readPump(store *HistoryStore) {
+	defer func() {
+		removeConnection(c.conn)
+		close(c.send)
+		c.logger.Info("websocket connection closed")
+	}()
+
+	c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	c.conn.SetPongHandler(func(string) error {
+		c.conn.SetReadDeadline(time.Now().Add(pongWait))
+		return nil
+	})
+
+	for {
+		_, raw, err := c.conn.ReadMessage()
+		if err != nil {
+			break
+		}
+
+		var req subscribeRequest
+		if err := json.Unmarshal(raw, &req); err != nil || len(req.Subscribe) == 0 {
+			continue
+		}
+
+		now := time.Now().Unix()
+		backfill := make(map[string][]Point, len(req.Subscribe))
+		for _, metric := range req.Subscribe {
+			backfill[metric] = store.Query(metric, req.Since, now)
+		}
+
+		data, err := json.Marshal(map[string]interface{}{"type": "backfill", "data": backfill})
+		if err != nil {
+			c.logger.Warn("failed to marshal backfill", zap.Error(err))
+			continue
+		}
+		c.enqueue(data)
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import "testing"
+
+// TestEnqueueDropsOldestWhenFull exercises the backpressure path: once a
+// client's send buffer is full, enqueue must drop the oldest queued message
+// rather than the new one, so writePump always catches up to the latest
+// state instead of replaying a stale backlog.
+func TestEnqueueDropsOldestWhenFull(t *testing.T) {
+	c := &wsClient{send: make(chan []byte, 2)}
+
+	c.enqueue([]byte("1"))
+	c.enqueue([]byte("2"))
+	c.enqueue([]byte("3")) // buffer full at 2: should drop "1", keep "2", add "3"
+
+	first := <-c.send
+	second := <-c.send
+	if string(first) != "2" || string(second) != "3" {
+		t.Errorf("got messages %q, %q; want %q, %q (oldest dropped)", first, second, "2", "3")
+	}
+}
+
+func TestEnqueueDoesNotBlockWhenFull(t *testing.T) {
+	c := &wsClient{send: make(chan []byte, 1)}
+	c.enqueue([]byte("1"))
+
+	done := make(chan struct{})
+	go func() {
+		c.enqueue([]byte("2"))
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	default:
+	}
+	<-done // enqueue must return promptly even with a full, unread buffer
+}
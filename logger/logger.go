@@ -0,0 +1,35 @@
+// Package logger provides the module's single zap.Logger instance: JSON
+// output in production, human-readable console output in development. This
+// is a prerequisite for shipping logs to Loki/ELK, where unstructured
+// log.Println output is hard to parse and correlate.
+package logger
+
+import (
+	"os"
+
+	"go.uber.org/zap"
+)
+
+// L is the process-wide logger, initialized by Init. Callers that need a
+// connection-scoped logger should use L.With(...) rather than mutating this
+// value.
+var L *zap.Logger
+
+// Init builds L according to APP_ENV: "production" gets JSON logs suited to
+// a log shipper, anything else gets zap's development console encoder. It
+// must be called once before L is used; main does this at startup.
+func Init() error {
+	var cfg zap.Config
+	if os.Getenv("APP_ENV") == "production" {
+		cfg = zap.NewProductionConfig()
+	} else {
+		cfg = zap.NewDevelopmentConfig()
+	}
+
+	built, err := cfg.Build()
+	if err != nil {
+		return err
+	}
+	L = built
+	return nil
+}
@@ -1,22 +1,28 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
-	"fmt"
-	"log"
 	"math"
 	"net/http"
 	"os/exec"
 	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/shirou/gopsutil/net"
 	"github.com/shirou/gopsutil/v3/cpu"
 	"github.com/shirou/gopsutil/v3/disk"
 	"github.com/shirou/gopsutil/v3/mem"
+	"github.com/spidey52/server-managment/alerts"
+	"github.com/spidey52/server-managment/logger"
+	"github.com/spidey52/server-managment/middleware/auth"
+	"github.com/spidey52/server-managment/middleware/requestlog"
+	"go.uber.org/zap"
 )
 
 var upgrader = websocket.Upgrader{
@@ -27,13 +33,14 @@ var upgrader = websocket.Upgrader{
 	},
 }
 
-var connections = make(map[*websocket.Conn]bool)
+var connections = make(map[*websocket.Conn]*wsClient)
 var connLock sync.Mutex
 var netLock sync.Mutex
+var nextConnID int64
 
-func addConnection(conn *websocket.Conn) {
+func addConnection(conn *websocket.Conn, c *wsClient) {
 	connLock.Lock()
-	connections[conn] = true
+	connections[conn] = c
 	connLock.Unlock()
 }
 
@@ -61,8 +68,9 @@ type Metrics struct {
 		Free  uint64 `json:"free"`
 		Used  uint64 `json:"used"`
 	} `json:"disk"`
-	Network []NetworkUsage `json:"network"`
-	PM2     []PM2Process   `json:"pm2"`
+	Network    []NetworkUsage   `json:"network"`
+	PM2        []PM2Process     `json:"pm2"`
+	Containers []ContainerStats `json:"containers,omitempty"`
 }
 
 type PM2Process struct {
@@ -73,9 +81,23 @@ type PM2Process struct {
 		Memory int `json:"memory"`
 		CPU    int `json:"cpu"`
 	} `json:"monit"`
-	PM2Env struct {
-		Status string `json:"status"`
-	} `json:"pm2_env"`
+	PM2Env PM2Env `json:"pm2_env"`
+}
+
+// PM2Env is the subset of pm2's `pm2_env` block this module cares about.
+type PM2Env struct {
+	Status      string `json:"status"`
+	RestartTime int    `json:"restart_time"`
+	PMUptime    int64  `json:"pm_uptime"`
+}
+
+// uptimeSeconds reports how long the process has been running, based on the
+// epoch-millisecond timestamp pm2 records the last time it started it.
+func (e PM2Env) uptimeSeconds() float64 {
+	if e.PMUptime == 0 {
+		return 0
+	}
+	return time.Since(time.UnixMilli(e.PMUptime)).Seconds()
 }
 
 func truncateToDecimals(value float64, precision int) float64 {
@@ -94,7 +116,20 @@ func getPm2Metrics() ([]PM2Process, error) {
 	return processes, err
 }
 
-func getMetrics(networkMetrics map[string]NetworkUsage) (Metrics, error) {
+// networkDelta computes how many bytes a monotonic interface counter
+// advanced between two samples. If curr is behind prev, the interface
+// itself reset (NIC reset, recreated virtual/container interface, etc) -
+// not negative bytes sent - so the uint64 subtraction is skipped in favor
+// of 0 rather than wrapping to near 2^64 and getting baked into a
+// Prometheus counter permanently via Add.
+func networkDelta(curr, prev uint64) uint64 {
+	if curr < prev {
+		return 0
+	}
+	return curr - prev
+}
+
+func getMetrics(networkMetrics map[string]NetworkUsage, containers *ContainerCollector) (Metrics, error) {
 	var metrics Metrics
 
 	cpuPercent, err := cpu.Percent(0, true)
@@ -132,8 +167,8 @@ func getMetrics(networkMetrics map[string]NetworkUsage) (Metrics, error) {
 		deltaSent := netStat.BytesSent
 		deltaRecv := netStat.BytesRecv
 		if prev, ok := networkMetrics[netStat.Name]; ok {
-			deltaSent -= prev.BytesSent
-			deltaRecv -= prev.BytesRecv
+			deltaSent = networkDelta(netStat.BytesSent, prev.BytesSent)
+			deltaRecv = networkDelta(netStat.BytesRecv, prev.BytesRecv)
 		}
 		networkMetrics[netStat.Name] = NetworkUsage{
 			Name:      netStat.Name,
@@ -156,47 +191,132 @@ func getMetrics(networkMetrics map[string]NetworkUsage) (Metrics, error) {
 		metrics.PM2 = pm2Metrics
 	}
 
+	if containers != nil && containers.Enabled() {
+		containerStats, err := containers.Collect(context.Background())
+		if err != nil {
+			logger.L.Warn("failed to collect container stats", zap.Error(err))
+		} else {
+			metrics.Containers = containerStats
+		}
+	}
+
 	return metrics, nil
 }
 
-func sendMetrics() {
+func sendMetrics(store *HistoryStore, alertSamples chan<- map[string]float64, containers *ContainerCollector) {
 	networkMetrics := make(map[string]NetworkUsage)
 	for {
 		time.Sleep(1 * time.Second)
+		tickStart := time.Now()
 
-		connLock.Lock()
-		if len(connections) == 0 {
-			connLock.Unlock()
+		// Collection (and the history store / Prometheus gauges it feeds)
+		// runs regardless of whether any WebSocket client is connected, so
+		// /history, /snapshot and /prom stay accurate even with zero live
+		// viewers.
+		metrics, err := getMetrics(networkMetrics, containers)
+		if err != nil {
+			logger.L.Error("failed to collect metrics", zap.Error(err))
 			continue
 		}
-		connLock.Unlock()
 
-		metrics, err := getMetrics(networkMetrics)
+		flat := flattenMetrics(metrics)
+		store.Add(time.Now().Unix(), flat)
+		updatePrometheusMetrics(metrics)
+
+		// Non-blocking: a slow alert evaluator must never stall metric
+		// collection or WebSocket writes.
+		select {
+		case alertSamples <- flat:
+		default:
+		}
+
+		data, err := json.Marshal(metrics)
 		if err != nil {
-			log.Println("Failed to get metrics:", err)
+			logger.L.Error("failed to marshal metrics", zap.Error(err))
 			continue
 		}
 
 		connLock.Lock()
-		for conn := range connections {
-			err := conn.WriteJSON(metrics)
-			if err != nil {
-				log.Println("Failed to write to websocket:", err)
-				conn.Close()
-				delete(connections, conn)
-			}
+		for _, c := range connections {
+			c.enqueue(data)
 		}
 		connLock.Unlock()
+
+		logger.L.Debug("sendMetrics tick", zap.Duration("duration", time.Since(tickStart)))
 	}
 }
 
+// subscribeRequest is the WebSocket subprotocol message clients may send
+// right after connecting to request history back-fill before live
+// streaming resumes: {"subscribe":["cpu.0","pm2.api.cpu"], "since": <ts>}.
+type subscribeRequest struct {
+	Subscribe []string `json:"subscribe"`
+	Since     int64    `json:"since"`
+}
+
+// authUser returns the subject of the JWT claims the auth middleware
+// attached to the request context, or "anonymous" if auth is disabled or
+// the claims are missing.
+func authUser(c *gin.Context) string {
+	claims, ok := c.Get("auth_claims")
+	if !ok {
+		return "anonymous"
+	}
+	if authClaims, ok := claims.(*auth.Claims); ok {
+		return authClaims.Subject
+	}
+	return "anonymous"
+}
+
+// requireScope wraps auth.Config.Require, but passes requests straight
+// through when authConfig is nil so the server keeps working for local
+// development that hasn't set up config.yaml / AUTH_SIGNING_KEY yet.
+func requireScope(authConfig *auth.Config, scopes ...string) gin.HandlerFunc {
+	if authConfig == nil {
+		return func(c *gin.Context) {}
+	}
+	return authConfig.Require(scopes...)
+}
+
 func main() {
+	if err := logger.Init(); err != nil {
+		panic(err)
+	}
+	defer logger.L.Sync()
+
 	gin.SetMode(gin.ReleaseMode)
-	server := gin.Default()
+	server := gin.New()
+	server.Use(gin.Recovery(), requestlog.New(logger.L))
 
-	go sendMetrics()
+	historyStore := NewHistoryStore()
+	pm2Controller := NewPM2Controller()
+
+	containerCollector, err := NewContainerCollector()
+	if err != nil {
+		logger.L.Warn("container metrics disabled", zap.Error(err))
+		containerCollector = nil
+	}
+
+	authConfig, err := auth.LoadConfig("config.yaml")
+	if err != nil {
+		logger.L.Warn("auth disabled", zap.Error(err))
+	}
 
-	server.GET("/metrics", func(c *gin.Context) {
+	alertsConfig, err := alerts.LoadConfig("config.yaml")
+	if err != nil {
+		logger.L.Warn("alerts disabled", zap.Error(err))
+		alertsConfig = &alerts.Config{}
+	}
+	alertEvaluator := alerts.NewEvaluator(alertsConfig.Rules, alertsConfig.ActiveNotifiers())
+	alertEvaluator.OnNotifyError = func(ruleID string, err error) {
+		logger.L.Warn("alert notifier failed", zap.String("rule_id", ruleID), zap.Error(err))
+	}
+	alertSamples := make(chan map[string]float64, 16)
+	go alertEvaluator.Run(alertSamples)
+
+	go sendMetrics(historyStore, alertSamples, containerCollector)
+
+	server.GET("/ws", requireScope(authConfig, "metrics:read"), func(c *gin.Context) {
 		ws, err := upgrader.Upgrade(c.Writer, c.Request, nil)
 		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{
@@ -206,21 +326,36 @@ func main() {
 			return
 		}
 
-		addConnection(ws)
-
-		go func(conn *websocket.Conn) {
-			defer func() {
-				conn.Close()
-				removeConnection(conn)
-			}()
-			for {
-				if _, _, err := conn.NextReader(); err != nil {
-					break
-				}
-			}
-		}(ws)
+		connID := atomic.AddInt64(&nextConnID, 1)
+		connLogger := logger.L.With(
+			zap.Int64("conn_id", connID),
+			zap.String("remote_addr", c.Request.RemoteAddr),
+			zap.String("user", authUser(c)),
+		)
+		connLogger.Info("websocket connection opened")
+
+		cl := newClient(ws, connLogger)
+		addConnection(ws, cl)
+
+		go cl.writePump()
+		go cl.readPump(historyStore)
 	})
 
-	fmt.Println("Server running on port 8082")
+	server.GET("/prom", requireScope(authConfig, "metrics:read"), gin.WrapH(promhttp.Handler()))
+
+	server.GET("/history", requireScope(authConfig, "metrics:read"), historyHandler(historyStore))
+	server.GET("/snapshot", requireScope(authConfig, "metrics:read"), snapshotHandler(historyStore))
+
+	server.POST("/pm2/:id/restart", requireScope(authConfig, "pm2:write"), pm2ActionHandler(pm2Controller.Restart))
+	server.POST("/pm2/:id/stop", requireScope(authConfig, "pm2:write"), pm2ActionHandler(pm2Controller.Stop))
+	server.POST("/pm2/:id/start", requireScope(authConfig, "pm2:write"), pm2ActionHandler(pm2Controller.Start))
+	server.POST("/pm2/:id/reload", requireScope(authConfig, "pm2:write"), pm2ActionHandler(pm2Controller.Reload))
+	server.DELETE("/pm2/:id", requireScope(authConfig, "pm2:write"), pm2ActionHandler(pm2Controller.Delete))
+	server.GET("/pm2/:id/logs", requireScope(authConfig, "pm2:read"), pm2LogsHandler)
+
+	server.GET("/alerts", requireScope(authConfig, "metrics:read"), alertsHandler(alertEvaluator))
+	server.POST("/alerts/:id/silence", requireScope(authConfig, "alerts:write"), alertsSilenceHandler(alertEvaluator))
+
+	logger.L.Info("server starting", zap.String("port", "8082"))
 	server.Run(":8082")
 }
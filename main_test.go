@@ -0,0 +1,19 @@
+package main
+
+import "testing"
+
+// TestNetworkDeltaHandlesCounterReset reproduces a regression where a
+// network interface counter going backwards (NIC reset, recreated
+// virtual/container interface) wrapped a uint64 subtraction to near 2^64,
+// which got baked into the Prometheus counter forever via Add.
+func TestNetworkDeltaHandlesCounterReset(t *testing.T) {
+	if got := networkDelta(1500, 1000); got != 500 {
+		t.Fatalf("normal advance: got %d, want 500", got)
+	}
+	if got := networkDelta(100, 1000); got != 0 {
+		t.Fatalf("counter reset should yield 0, got %d (would have wrapped to a huge uint64)", got)
+	}
+	if got := networkDelta(0, 0); got != 0 {
+		t.Fatalf("no advance: got %d, want 0", got)
+	}
+}
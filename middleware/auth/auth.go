@@ -0,0 +1,112 @@
+// Package auth provides a pluggable gin middleware that guards routes with
+// signed JWT bearer tokens, configured via config.yaml (or environment
+// variables for deployments that don't ship a config file).
+package auth
+
+import (
+	"errors"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"gopkg.in/yaml.v3"
+)
+
+// Config declares the signing key, allowed audiences, and is loaded once at
+// startup and shared by every route's middleware instance.
+type Config struct {
+	SigningKey string   `yaml:"signing_key"`
+	Audiences  []string `yaml:"audiences"`
+}
+
+// Claims is the JWT payload this module expects. Scopes gate access to
+// individual routes, e.g. "metrics:read", "pm2:write".
+type Claims struct {
+	jwt.RegisteredClaims
+	Scopes []string `json:"scopes"`
+}
+
+// LoadConfig reads signing key and audiences from the YAML file at path,
+// falling back to AUTH_SIGNING_KEY / AUTH_AUDIENCES (comma-separated)
+// environment variables for any field the file doesn't set. A missing file
+// is not an error as long as the environment provides a signing key.
+func LoadConfig(path string) (*Config, error) {
+	cfg := &Config{}
+
+	if data, err := os.ReadFile(path); err == nil {
+		if err := yaml.Unmarshal(data, cfg); err != nil {
+			return nil, err
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	if cfg.SigningKey == "" {
+		cfg.SigningKey = os.Getenv("AUTH_SIGNING_KEY")
+	}
+	if len(cfg.Audiences) == 0 {
+		if raw := os.Getenv("AUTH_AUDIENCES"); raw != "" {
+			cfg.Audiences = strings.Split(raw, ",")
+		}
+	}
+
+	if cfg.SigningKey == "" {
+		return nil, errors.New("auth: no signing key configured (set signing_key in config.yaml or AUTH_SIGNING_KEY)")
+	}
+	return cfg, nil
+}
+
+// Require returns a gin middleware that rejects requests without a valid,
+// unexpired JWT carrying every scope in requiredScopes. The token may be
+// supplied via the "Authorization: Bearer <token>" header or a "?token="
+// query parameter for browser WebSocket clients that can't set headers.
+func (c *Config) Require(requiredScopes ...string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		raw := extractToken(ctx)
+		if raw == "" {
+			ctx.AbortWithStatusJSON(401, gin.H{"error": "missing_token", "message": "no bearer token supplied"})
+			return
+		}
+
+		claims := &Claims{}
+		_, err := jwt.ParseWithClaims(raw, claims, func(t *jwt.Token) (interface{}, error) {
+			if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+				return nil, errors.New("unexpected signing method")
+			}
+			return []byte(c.SigningKey), nil
+		}, jwt.WithAudience(c.Audiences...))
+		if err != nil {
+			ctx.AbortWithStatusJSON(401, gin.H{"error": "invalid_token", "message": err.Error()})
+			return
+		}
+
+		if !hasAllScopes(claims.Scopes, requiredScopes) {
+			ctx.AbortWithStatusJSON(403, gin.H{"error": "insufficient_scope", "message": "token is missing a required scope"})
+			return
+		}
+
+		ctx.Set("auth_claims", claims)
+		ctx.Next()
+	}
+}
+
+func extractToken(c *gin.Context) string {
+	if header := c.GetHeader("Authorization"); strings.HasPrefix(header, "Bearer ") {
+		return strings.TrimPrefix(header, "Bearer ")
+	}
+	return c.Query("token")
+}
+
+func hasAllScopes(have, want []string) bool {
+	set := make(map[string]bool, len(have))
+	for _, s := range have {
+		set[s] = true
+	}
+	for _, s := range want {
+		if !set[s] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,62 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newTestContext(t *testing.T, target string, header string) *gin.Context {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	if header != "" {
+		req.Header.Set("Authorization", header)
+	}
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = req
+	return c
+}
+
+func TestExtractTokenPrefersHeaderOverQuery(t *testing.T) {
+	c := newTestContext(t, "/ws?token=query-token", "Bearer header-token")
+	if got := extractToken(c); got != "header-token" {
+		t.Fatalf("got %q, want header-token", got)
+	}
+}
+
+func TestExtractTokenFallsBackToQuery(t *testing.T) {
+	c := newTestContext(t, "/ws?token=query-token", "")
+	if got := extractToken(c); got != "query-token" {
+		t.Fatalf("got %q, want query-token", got)
+	}
+}
+
+func TestExtractTokenEmptyWhenNeitherSupplied(t *testing.T) {
+	c := newTestContext(t, "/ws", "")
+	if got := extractToken(c); got != "" {
+		t.Fatalf("got %q, want empty string", got)
+	}
+}
+
+func TestExtractTokenIgnoresNonBearerHeader(t *testing.T) {
+	c := newTestContext(t, "/ws?token=query-token", "Basic dXNlcjpwYXNz")
+	if got := extractToken(c); got != "query-token" {
+		t.Fatalf("got %q, want query-token (non-Bearer header should be ignored)", got)
+	}
+}
+
+func TestHasAllScopesRequiresEveryScope(t *testing.T) {
+	have := []string{"metrics:read", "pm2:write"}
+	if !hasAllScopes(have, []string{"metrics:read"}) {
+		t.Error("expected a subset of scopes to satisfy the requirement")
+	}
+	if !hasAllScopes(have, nil) {
+		t.Error("expected no required scopes to always be satisfied")
+	}
+	if hasAllScopes(have, []string{"metrics:read", "admin:all"}) {
+		t.Error("expected a missing scope to fail the check")
+	}
+}
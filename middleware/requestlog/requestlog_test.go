@@ -0,0 +1,26 @@
+package requestlog
+
+import "testing"
+
+// TestRedactQueryStripsToken reproduces a regression where gin's default
+// Logger() middleware wrote the raw ?token=... query string straight to
+// stdout, leaking the bearer JWT used for WebSocket auth.
+func TestRedactQueryStripsToken(t *testing.T) {
+	got := redactQuery("token=super-secret-jwt&metric=cpu.0")
+	if got != "metric=cpu.0&token=REDACTED" {
+		t.Fatalf("expected token to be redacted, got %q", got)
+	}
+}
+
+func TestRedactQueryLeavesOtherParamsAlone(t *testing.T) {
+	got := redactQuery("from=1&to=7200")
+	if got != "from=1&to=7200" {
+		t.Fatalf("expected unrelated params untouched, got %q", got)
+	}
+}
+
+func TestRedactQueryEmpty(t *testing.T) {
+	if got := redactQuery(""); got != "" {
+		t.Fatalf("expected empty query to stay empty, got %q", got)
+	}
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"net/http"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+// PM2Controller serializes calls into the `pm2` CLI so that concurrent HTTP
+// callers (restart + stop + delete racing each other) can't stomp on one
+// another; pm2 itself has no compare-and-swap semantics, so we just take
+// turns.
+type PM2Controller struct {
+	mu sync.Mutex
+}
+
+func NewPM2Controller() *PM2Controller {
+	return &PM2Controller{}
+}
+
+// pm2CommandError distinguishes the failure modes callers care about:
+// pm2 missing from PATH, an id pm2 doesn't recognize, or everything else.
+type pm2CommandError struct {
+	Kind    string
+	Message string
+}
+
+func (e *pm2CommandError) Error() string {
+	return e.Message
+}
+
+func (p *PM2Controller) run(args ...string) ([]byte, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	cmd := exec.Command("pm2", args...)
+	output, err := cmd.CombinedOutput()
+	if err == nil {
+		return output, nil
+	}
+	return output, classifyPM2Error(err, output, args[len(args)-1])
+}
+
+// classifyPM2Error maps a failed pm2 invocation to the pm2CommandError kind
+// callers (and writePM2Error) branch on: pm2 missing from PATH, an id pm2
+// doesn't recognize (pm2 reports this as "process or namespace not found"
+// rather than a distinct exit code), or everything else.
+func classifyPM2Error(err error, output []byte, id string) *pm2CommandError {
+	var execErr *exec.Error
+	if errors.As(err, &execErr) {
+		return &pm2CommandError{Kind: "pm2_not_installed", Message: "pm2 is not installed or not on PATH"}
+	}
+	if strings.Contains(strings.ToLower(string(output)), "process or namespace not found") {
+		return &pm2CommandError{Kind: "unknown_id", Message: "no pm2 process matches id " + id}
+	}
+	return &pm2CommandError{Kind: "exec_failed", Message: strings.TrimSpace(string(output))}
+}
+
+func (p *PM2Controller) Restart(id string) ([]byte, error) { return p.run("restart", id) }
+func (p *PM2Controller) Stop(id string) ([]byte, error)    { return p.run("stop", id) }
+func (p *PM2Controller) Start(id string) ([]byte, error)   { return p.run("start", id) }
+func (p *PM2Controller) Reload(id string) ([]byte, error)  { return p.run("reload", id) }
+func (p *PM2Controller) Delete(id string) ([]byte, error)  { return p.run("delete", id) }
+
+// pm2ActionHandler wraps a PM2Controller method that takes an id and returns
+// the raw CLI output, translating pm2CommandError into the matching HTTP
+// status and a structured error response.
+func pm2ActionHandler(action func(id string) ([]byte, error)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.Param("id")
+		output, err := action(id)
+		if err != nil {
+			writePM2Error(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"id": id, "output": string(output)})
+	}
+}
+
+func writePM2Error(c *gin.Context, err error) {
+	var pmErr *pm2CommandError
+	if errors.As(err, &pmErr) {
+		status := http.StatusInternalServerError
+		switch pmErr.Kind {
+		case "pm2_not_installed":
+			status = http.StatusServiceUnavailable
+		case "unknown_id":
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": pmErr.Kind, "message": pmErr.Message})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "exec_failed", "message": err.Error()})
+}
+
+// pm2LogsHandler tails `pm2 logs <id> --raw` and streams each line to the
+// client over a WebSocket. Lines are buffered on a bounded channel so a slow
+// client can't block the tailing goroutine; once the buffer is full the
+// oldest line is dropped. A ping ticker keeps the connection alive and lets
+// us detect a dead client via write deadline.
+func pm2LogsHandler(c *gin.Context) {
+	id := c.Param("id")
+
+	ws, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "upgrade_failed", "message": err.Error()})
+		return
+	}
+	defer ws.Close()
+
+	cmd := exec.Command("pm2", "logs", id, "--raw")
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		ws.WriteJSON(gin.H{"error": "exec_failed", "message": err.Error()})
+		return
+	}
+	if err := cmd.Start(); err != nil {
+		ws.WriteJSON(gin.H{"error": "pm2_not_installed", "message": err.Error()})
+		return
+	}
+	defer cmd.Process.Kill()
+
+	lines := make(chan string, 256)
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(stdout)
+		for scanner.Scan() {
+			select {
+			case lines <- scanner.Text():
+			default:
+				<-lines
+				lines <- scanner.Text()
+			}
+		}
+	}()
+
+	ping := time.NewTicker(30 * time.Second)
+	defer ping.Stop()
+
+	for {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				return
+			}
+			ws.SetWriteDeadline(time.Now().Add(10 * time.Second))
+			if err := ws.WriteMessage(websocket.TextMessage, []byte(line)); err != nil {
+				return
+			}
+		case <-ping.C:
+			ws.SetWriteDeadline(time.Now().Add(10 * time.Second))
+			if err := ws.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestClassifyPM2ErrorUnknownID(t *testing.T) {
+	err := classifyPM2Error(errors.New("exit status 1"), []byte("[PM2][ERROR] Process or Namespace not found"), "web")
+	if err.Kind != "unknown_id" {
+		t.Fatalf("expected kind unknown_id, got %q", err.Kind)
+	}
+}
+
+func TestClassifyPM2ErrorNotInstalled(t *testing.T) {
+	execErr := &exec.Error{Name: "pm2", Err: exec.ErrNotFound}
+	err := classifyPM2Error(execErr, nil, "web")
+	if err.Kind != "pm2_not_installed" {
+		t.Fatalf("expected kind pm2_not_installed, got %q", err.Kind)
+	}
+}
+
+func TestClassifyPM2ErrorGenericFailure(t *testing.T) {
+	err := classifyPM2Error(errors.New("exit status 1"), []byte("some other pm2 failure"), "web")
+	if err.Kind != "exec_failed" {
+		t.Fatalf("expected kind exec_failed, got %q", err.Kind)
+	}
+}
+
+func TestWritePM2ErrorStatusCodes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cases := []struct {
+		err        error
+		wantStatus int
+	}{
+		{&pm2CommandError{Kind: "pm2_not_installed", Message: "missing"}, http.StatusServiceUnavailable},
+		{&pm2CommandError{Kind: "unknown_id", Message: "no such id"}, http.StatusNotFound},
+		{&pm2CommandError{Kind: "exec_failed", Message: "boom"}, http.StatusInternalServerError},
+		{errors.New("not a pm2CommandError"), http.StatusInternalServerError},
+	}
+
+	for _, tc := range cases {
+		rec := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(rec)
+		writePM2Error(c, tc.err)
+		if rec.Code != tc.wantStatus {
+			t.Errorf("err %v: got status %d, want %d", tc.err, rec.Code, tc.wantStatus)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Prometheus exposition lives alongside the WebSocket stream so existing
+// Prometheus/Grafana stacks can scrape GET /prom instead of requiring a
+// bespoke WebSocket consumer. Network counters are registered as counters
+// and Add()-ed with the same per-tick deltas sendMetrics already computes,
+// so they grow monotonically with process uptime like a real counter
+// would. PM2 fields (including "restarts") come back from pm2 as already
+// cumulative totals rather than deltas, so they're exposed as gauges to
+// avoid tripping prometheus's "counter cannot decrease" guard if pm2 itself
+// ever resets them.
+var (
+	promCPUPercent = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "server_cpu_percent",
+		Help: "Per-core CPU utilization percentage.",
+	}, []string{"core"})
+
+	promMemoryBytes = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "server_memory_bytes",
+		Help: "Memory usage in bytes.",
+	}, []string{"state"})
+
+	promDiskBytes = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "server_disk_bytes",
+		Help: "Disk usage in bytes for /.",
+	}, []string{"state"})
+
+	promNetBytesSentTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "server_network_bytes_sent_total",
+		Help: "Cumulative bytes sent per network interface.",
+	}, []string{"interface"})
+
+	promNetBytesRecvTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "server_network_bytes_recv_total",
+		Help: "Cumulative bytes received per network interface.",
+	}, []string{"interface"})
+
+	promPM2CPUPercent = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "pm2_process_cpu_percent",
+		Help: "PM2 process CPU utilization percentage.",
+	}, []string{"name", "pm_id"})
+
+	promPM2MemoryBytes = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "pm2_process_memory_bytes",
+		Help: "PM2 process memory usage in bytes.",
+	}, []string{"name", "pm_id"})
+
+	promPM2Up = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "pm2_process_up",
+		Help: "1 if the PM2 process status is online, 0 otherwise.",
+	}, []string{"name", "pm_id"})
+
+	promPM2UptimeSeconds = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "pm2_process_uptime_seconds",
+		Help: "Seconds since the PM2 process last started.",
+	}, []string{"name", "pm_id"})
+
+	promPM2Restarts = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "pm2_process_restarts_total",
+		Help: "Number of times PM2 has restarted this process.",
+	}, []string{"name", "pm_id"})
+
+	promDroppedSamplesTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "dropped_samples_total",
+		Help: "Samples dropped from a client's send buffer because it couldn't keep up.",
+	})
+)
+
+// updatePrometheusMetrics mirrors one Metrics sample into the registered
+// collectors above. It's called from the same tick as the WebSocket
+// broadcast so both exposition formats stay in sync.
+func updatePrometheusMetrics(m Metrics) {
+	for i, v := range m.CPU {
+		promCPUPercent.WithLabelValues(strconv.Itoa(i)).Set(v)
+	}
+
+	promMemoryBytes.WithLabelValues("total").Set(float64(m.Memory.Total))
+	promMemoryBytes.WithLabelValues("free").Set(float64(m.Memory.Free))
+	promMemoryBytes.WithLabelValues("used").Set(float64(m.Memory.Used))
+
+	promDiskBytes.WithLabelValues("total").Set(float64(m.Disk.Total))
+	promDiskBytes.WithLabelValues("free").Set(float64(m.Disk.Free))
+	promDiskBytes.WithLabelValues("used").Set(float64(m.Disk.Used))
+
+	for _, n := range m.Network {
+		promNetBytesSentTotal.WithLabelValues(n.Name).Add(float64(n.BytesSent))
+		promNetBytesRecvTotal.WithLabelValues(n.Name).Add(float64(n.BytesRecv))
+	}
+
+	for _, p := range m.PM2 {
+		pmID := strconv.Itoa(p.PM2ID)
+		promPM2CPUPercent.WithLabelValues(p.Name, pmID).Set(float64(p.Monit.CPU))
+		promPM2MemoryBytes.WithLabelValues(p.Name, pmID).Set(float64(p.Monit.Memory))
+		up := 0.0
+		if p.PM2Env.Status == "online" {
+			up = 1.0
+		}
+		promPM2Up.WithLabelValues(p.Name, pmID).Set(up)
+		promPM2UptimeSeconds.WithLabelValues(p.Name, pmID).Set(p.PM2Env.uptimeSeconds())
+		promPM2Restarts.WithLabelValues(p.Name, pmID).Set(float64(p.PM2Env.RestartTime))
+	}
+}